@@ -0,0 +1,158 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"net/http"
+	"time"
+)
+
+const healthShutdownTimeout = 5 * time.Second
+
+// healthService serves the /livez, /readyz, /health (and, when the watcher
+// subsystem is enabled, /watchers) endpoints. It implements Service so main
+// can supervise it alongside the poller and shut it down gracefully instead
+// of relying on log.Fatalf.
+type healthService struct {
+	mux     *http.ServeMux
+	server  *http.Server
+	done    chan struct{}
+	limiter *RedditLimiter
+}
+
+// newHealthService builds a healthService listening on port. Callers that
+// want to mount additional routes (e.g. registerWatcherRoutes) can do so via
+// the Mux method before calling Start.
+func newHealthService(port string) *healthService {
+	mux := http.NewServeMux()
+	s := &healthService{
+		mux:    mux,
+		server: &http.Server{Addr: ":" + port, Handler: mux},
+	}
+	s.registerRoutes()
+	return s
+}
+
+// Mux exposes the ServeMux so other subsystems (e.g. the watcher CRUD API)
+// can register additional routes before Start is called.
+func (s *healthService) Mux() *http.ServeMux {
+	return s.mux
+}
+
+// SetLimiter attaches a RedditLimiter whose bucket state is reported on
+// /health. Call before Start; it's not safe to change once the server is
+// serving requests.
+func (s *healthService) SetLimiter(limiter *RedditLimiter) {
+	s.limiter = limiter
+}
+
+func (s *healthService) registerRoutes() {
+	// Livez endpoint - basic aliveness check
+	s.mux.HandleFunc("/livez", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(map[string]string{"status": "alive"})
+	})
+
+	// Readyz endpoint - checks if dependencies are ready
+	s.mux.HandleFunc("/readyz", func(w http.ResponseWriter, r *http.Request) {
+		appState.RLock()
+		redisOK := appState.redisConnected
+		redditOK := appState.redditConnected
+		appState.RUnlock()
+
+		w.Header().Set("Content-Type", "application/json")
+
+		if redisOK && redditOK {
+			w.WriteHeader(http.StatusOK)
+			json.NewEncoder(w).Encode(map[string]string{"status": "ready"})
+		} else {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			json.NewEncoder(w).Encode(map[string]interface{}{
+				"status":           "not_ready",
+				"redis_connected":  redisOK,
+				"reddit_connected": redditOK,
+			})
+		}
+	})
+
+	// Full health status endpoint
+	s.mux.HandleFunc("/health", func(w http.ResponseWriter, r *http.Request) {
+		appState.RLock()
+		redisOK := appState.redisConnected
+		redditOK := appState.redditConnected
+		lastPoll := appState.lastSuccessfulPoll
+		msgCount := appState.messageCount
+
+		// Get last 5 errors at most
+		errorCount := len(appState.errors)
+		recentErrors := []string{}
+		if errorCount > 0 {
+			startIdx := 0
+			if errorCount > 5 {
+				startIdx = errorCount - 5
+			}
+			recentErrors = appState.errors[startIdx:errorCount]
+		}
+		appState.RUnlock()
+
+		w.Header().Set("Content-Type", "application/json")
+
+		// Check if we have polled recently (last 30 seconds)
+		recentPoll := time.Since(lastPoll) < 30*time.Second
+
+		status := "healthy"
+		httpStatus := http.StatusOK
+
+		if !redisOK || !redditOK {
+			status = "unhealthy"
+			httpStatus = http.StatusServiceUnavailable
+		}
+
+		payload := map[string]interface{}{
+			"status":           status,
+			"redis_connected":  redisOK,
+			"reddit_connected": redditOK,
+			"last_poll":        lastPoll,
+			"recent_poll":      recentPoll,
+			"message_count":    msgCount,
+			"recent_errors":    recentErrors,
+		}
+		if s.limiter != nil {
+			remaining, resetAt := s.limiter.State()
+			payload["reddit_rate_limit"] = map[string]interface{}{
+				"remaining": remaining,
+				"reset_at":  resetAt,
+			}
+		}
+
+		w.WriteHeader(httpStatus)
+		json.NewEncoder(w).Encode(payload)
+	})
+}
+
+func (s *healthService) Start(ctx context.Context) error {
+	s.done = make(chan struct{})
+	go func() {
+		defer close(s.done)
+		log.Printf("Starting health server on port %s", s.server.Addr)
+		if err := s.server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			log.Printf("Health server failed: %v", err)
+			recordError("Health server failed: " + err.Error())
+		}
+	}()
+	return nil
+}
+
+func (s *healthService) Stop() error {
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), healthShutdownTimeout)
+	defer cancel()
+	return s.server.Shutdown(shutdownCtx)
+}
+
+func (s *healthService) Wait() {
+	if s.done != nil {
+		<-s.done
+	}
+}
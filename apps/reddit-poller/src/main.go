@@ -1,16 +1,16 @@
 package main
 
 import (
-	"container/list"
 	"context"
-	"encoding/json"
+	"database/sql"
 	"fmt"
 	"log"
-	"net/http"
 	"os"
+	"os/signal"
 	"strconv"
 	"strings"
 	"sync"
+	"syscall"
 	"time"
 
 	"github.com/go-redis/redis/v8"
@@ -21,22 +21,16 @@ const (
 	seenLimit = 500 // max posts to remember
 )
 
-var (
-	ctx       = context.Background()
-	seenPosts = make(map[string]*list.Element)
-	seenList  = list.New() // LRU: front = newest, back = oldest
-
-	// Health check state
-	appState struct {
-		sync.RWMutex
-		redisConnected        bool
-		redditConnected       bool
-		lastSuccessfulPoll    time.Time
-		messageCount          int
-		errors                []string
-		maxStoredErrors       int
-	}
-)
+// Health check state
+var appState struct {
+	sync.RWMutex
+	redisConnected     bool
+	redditConnected    bool
+	lastSuccessfulPoll time.Time
+	messageCount       int
+	errors             []string
+	maxStoredErrors    int
+}
 
 // Initialize app state
 func init() {
@@ -44,124 +38,64 @@ func init() {
 	appState.errors = make([]string, 0, appState.maxStoredErrors)
 }
 
-// Health check handlers
-func setupHealthServer(port string) {
-	// Livez endpoint - basic aliveness check
-	http.HandleFunc("/livez", func(w http.ResponseWriter, r *http.Request) {
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusOK)
-		json.NewEncoder(w).Encode(map[string]string{"status": "alive"})
-	})
-
-	// Readyz endpoint - checks if dependencies are ready
-	http.HandleFunc("/readyz", func(w http.ResponseWriter, r *http.Request) {
-		appState.RLock()
-		redisOK := appState.redisConnected
-		redditOK := appState.redditConnected
-		appState.RUnlock()
-
-		w.Header().Set("Content-Type", "application/json")
-
-		if redisOK && redditOK {
-			w.WriteHeader(http.StatusOK)
-			json.NewEncoder(w).Encode(map[string]string{"status": "ready"})
-		} else {
-			w.WriteHeader(http.StatusServiceUnavailable)
-			json.NewEncoder(w).Encode(map[string]interface{}{
-				"status":          "not_ready",
-				"redis_connected": redisOK,
-				"reddit_connected": redditOK,
-			})
-		}
-	})
-
-	// Full health status endpoint
-	http.HandleFunc("/health", func(w http.ResponseWriter, r *http.Request) {
-		appState.RLock()
-		redisOK := appState.redisConnected
-		redditOK := appState.redditConnected
-		lastPoll := appState.lastSuccessfulPoll
-		msgCount := appState.messageCount
-		
-		// Get last 5 errors at most
-		errorCount := len(appState.errors)
-		recentErrors := []string{}
-		if errorCount > 0 {
-			startIdx := 0
-			if errorCount > 5 {
-				startIdx = errorCount - 5
-			}
-			recentErrors = appState.errors[startIdx:errorCount]
-		}
-		appState.RUnlock()
-
-		w.Header().Set("Content-Type", "application/json")
-
-		// Check if we have polled recently (last 30 seconds)
-		recentPoll := time.Since(lastPoll) < 30*time.Second
-
-		status := "healthy"
-		httpStatus := http.StatusOK
-		
-		if !redisOK || !redditOK {
-			status = "unhealthy"
-			httpStatus = http.StatusServiceUnavailable
-		}
-
-		w.WriteHeader(httpStatus)
-		json.NewEncoder(w).Encode(map[string]interface{}{
-			"status":            status,
-			"redis_connected":   redisOK,
-			"reddit_connected":  redditOK,
-			"last_poll":         lastPoll,
-			"recent_poll":       recentPoll,
-			"message_count":     msgCount,
-			"recent_errors":     recentErrors,
-		})
-	})
+// publishPost fans a post out to every configured sink. appState bookkeeping
+// (redisConnected, messageCount) is each sink's own responsibility, not
+// publishPost's: redisStreamSink's batched path can't know its outcome until
+// well after Publish returns, so publishPost can't reliably reflect it in
+// the aggregate "did this post make it out" sense the way it used to.
+// publishPost only logs and records sink errors it's actually handed back.
+func publishPost(ctx context.Context, sinks []Sink, post *reddit.Post) {
+	internalPost := RedditPost{
+		ID:        post.ID,
+		Title:     post.Title,
+		Body:      post.Body,
+		URL:       post.URL,
+		Author:    post.Author,
+		Subreddit: post.SubredditName,
+		Created:   post.Created.Time,
+	}
 
-	// Start HTTP server in a goroutine
-	go func() {
-		log.Printf("Starting health server on port %s", port)
-		if err := http.ListenAndServe(":"+port, nil); err != nil {
-			log.Fatalf("Health server failed: %v", err)
+	for _, sink := range sinks {
+		if err := sink.Publish(ctx, internalPost); err != nil {
+			log.Printf("Sink publish failed: %v", err)
+			recordError("Sink publish failed: " + err.Error())
 		}
-	}()
-}
-
-func rememberPost(id string) {
-	// If already exists, move to front
-	if elem, exists := seenPosts[id]; exists {
-		seenList.MoveToFront(elem)
-		return
 	}
 
-	// Add to front
-	elem := seenList.PushFront(id)
-	seenPosts[id] = elem
-
-	// Trim if needed
-	if seenList.Len() > seenLimit {
-		tail := seenList.Back()
-		if tail != nil {
-			delete(seenPosts, tail.Value.(string))
-			seenList.Remove(tail)
+	if watcherEvaluator != nil {
+		if matched := watcherEvaluator.Evaluate(internalPost); len(matched) > 0 && watcherDispatch != nil {
+			// Dispatch is fanned out to every matched destination
+			// concurrently, but those destinations still get retried with
+			// backoff over several seconds each. Run it off the poll loop's
+			// goroutine entirely so one slow or unreachable webhook can't
+			// stall ingestion for every subreddit.
+			go watcherDispatch.Dispatch(ctx, matched, internalPost)
 		}
 	}
 }
 
-func hasSeen(id string) bool {
-	_, exists := seenPosts[id]
-	return exists
+// parseBoolEnv reads a boolean environment variable, falling back to def
+// when it's unset or malformed.
+func parseBoolEnv(name string, def bool) bool {
+	raw := os.Getenv(name)
+	if raw == "" {
+		return def
+	}
+	parsed, err := strconv.ParseBool(raw)
+	if err != nil {
+		log.Printf("Invalid %s %q, using default: %v", name, raw, err)
+		return def
+	}
+	return parsed
 }
 
 func recordError(err string) {
 	appState.Lock()
 	defer appState.Unlock()
-	
+
 	// Add error to the list
 	appState.errors = append(appState.errors, err)
-	
+
 	// Trim if needed
 	if len(appState.errors) > appState.maxStoredErrors {
 		appState.errors = appState.errors[1:]
@@ -207,9 +141,39 @@ func main() {
 		log.Printf("Monitoring subreddits from environment: %v", subreddits)
 	}
 
-	// Start health check server
-	setupHealthServer(healthPort)
-	log.Printf("Health check server started on port %s", healthPort)
+	// rootCtx is cancelled on SIGINT/SIGTERM; it's threaded into every
+	// Reddit/Redis/Postgres call so shutdown is prompt and in-flight writes
+	// get a chance to flush instead of the process dying mid-request.
+	rootCtx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	health := newHealthService(healthPort)
+
+	// Open the shared Postgres connection used by the watcher subsystem and,
+	// if configured, the durable dedup store.
+	var appDB *sql.DB
+	if dsn := os.Getenv("WATCHER_DB_DSN"); dsn != "" {
+		db, err := sql.Open("pgx", dsn)
+		if err != nil {
+			log.Printf("Failed to open Postgres database: %v", err)
+			recordError("Failed to open Postgres database: " + err.Error())
+		} else {
+			appDB = db
+		}
+	}
+
+	// Set up the watcher subsystem if Postgres is available.
+	if appDB != nil {
+		registry := newWatcherRegistry(newPostgresWatcherStore(appDB))
+		if err := registry.load(rootCtx); err != nil {
+			log.Printf("Failed to load watchers: %v", err)
+			recordError("Failed to load watchers: " + err.Error())
+		}
+		registerWatcherRoutes(health.Mux(), registry)
+		watcherEvaluator = registry
+		watcherDispatch = newWatcherDispatcher()
+		log.Printf("Watcher subsystem enabled")
+	}
 
 	// Initialize Redis client
 	redisClient := redis.NewClient(&redis.Options{
@@ -217,9 +181,41 @@ func main() {
 		Password: redisPass,
 		DB:       0,
 	})
+	defer redisClient.Close()
+
+	// Pick the dedup store. Defaults to the in-memory LRU, which does not
+	// survive a restart; set SEEN_STORE=redis or SEEN_STORE=postgres for a
+	// durable store.
+	seenStore := newSeenStore(os.Getenv("SEEN_STORE"), redisClient, appDB)
+
+	// Set up XAdd pipelining. REDIS_PIPELINE_WINDOW / REDIS_PIPELINE_LIMIT
+	// default to 50ms / 128; setting either to zero disables pipelining and
+	// falls back to a synchronous XAdd per post.
+	pipelineWindow := defaultPipelineWindow
+	if raw := os.Getenv("REDIS_PIPELINE_WINDOW"); raw != "" {
+		if parsed, err := time.ParseDuration(raw); err == nil {
+			pipelineWindow = parsed
+		} else {
+			log.Printf("Invalid REDIS_PIPELINE_WINDOW %q, using default: %v", raw, err)
+		}
+	}
+	pipelineLimit := defaultPipelineLimit
+	if raw := os.Getenv("REDIS_PIPELINE_LIMIT"); raw != "" {
+		if parsed, err := strconv.Atoi(raw); err == nil {
+			pipelineLimit = parsed
+		} else {
+			log.Printf("Invalid REDIS_PIPELINE_LIMIT %q, using default: %v", raw, err)
+		}
+	}
+
+	var streamBatcher *StreamBatcher
+	if shouldPipeline(pipelineWindow, pipelineLimit) {
+		streamBatcher = NewStreamBatcher(redisClient, pipelineWindow, pipelineLimit)
+		log.Printf("XAdd pipelining enabled (window=%s, limit=%d)", pipelineWindow, pipelineLimit)
+	}
 
 	// Test Redis connection
-	_, err := redisClient.Ping(ctx).Result()
+	_, err := redisClient.Ping(rootCtx).Result()
 	if err != nil {
 		log.Printf("Redis connection failed: %v", err)
 		recordError("Redis connection failed: " + err.Error())
@@ -249,99 +245,70 @@ func main() {
 		creds,
 		reddit.WithUserAgent(userAgent),
 	)
-
 	if err != nil {
+		// Bad credentials or malformed config: there is nothing a restart
+		// would fix, so this is fatal.
 		log.Fatalf("Failed to init Reddit client: %v", err)
-		recordError("Failed to init Reddit client: " + err.Error())
-		appState.Lock()
-		appState.redditConnected = false
-		appState.Unlock()
-	} else {
-		appState.Lock()
-		appState.redditConnected = true
-		appState.Unlock()
 	}
 
-	// Main polling loop
-	for {
-		sleepTime := 1 * time.Second // Default wait time
-		
-		// Loop through each subreddit
-		for _, subreddit := range subreddits {
-			posts, resp, err := client.Subreddit.NewPosts(context.Background(), subreddit, &reddit.ListOptions{Limit: 10})
-			
-			// Check rate limits and handle them appropriately
-			if resp != nil {
-				// Check Reddit rate limit headers
-				remaining, _ := strconv.Atoi(resp.Header.Get("X-Ratelimit-Remaining"))
-				resetTime, _ := strconv.Atoi(resp.Header.Get("X-Ratelimit-Reset"))
-				used, _ := strconv.Atoi(resp.Header.Get("X-Ratelimit-Used"))
-				
-				log.Printf("Rate limits for %s: %d used, %d remaining, %d seconds until reset", 
-					subreddit, used, remaining, resetTime)
-				
-				// If we're getting close to the limit, respect the reset time
-				if remaining < 10 {
-					log.Printf("Approaching rate limit! Slowing down for %d seconds", resetTime)
-					sleepTime = time.Duration(resetTime) * time.Second
-				}
-			}
-			
-			if err != nil {
-				log.Printf("Failed to fetch posts from %s: %v", subreddit, err)
-				recordError(fmt.Sprintf("Failed to fetch posts from %s: %v", subreddit, err.Error()))
-				appState.Lock()
-				appState.redditConnected = false
-				appState.Unlock()
-				continue // Skip to next subreddit
-			}
+	appState.Lock()
+	appState.redditConnected = true
+	appState.Unlock()
+
+	// Pick which sinks new posts are published to. The stream sink
+	// preserves the historical behavior and defaults on; the Pub/Sub sink
+	// is additive and defaults off.
+	var sinks []Sink
+	if parseBoolEnv("ENABLE_STREAM_SINK", true) {
+		sinks = append(sinks, newRedisStreamSink(redisClient, streamBatcher))
+	}
+	if parseBoolEnv("ENABLE_PUBSUB_SINK", false) {
+		sinks = append(sinks, newPubsubSink(redisClient))
+		log.Printf("Pub/Sub sink enabled (reddit-events:<subreddit>)")
+	}
 
-			// Update Reddit connection status and last successful poll time
-			appState.Lock()
-			appState.redditConnected = true
-			appState.lastSuccessfulPoll = time.Now()
-			appState.Unlock()
-
-			for _, post := range posts {
-				// Skip if we've already seen this post
-				if hasSeen(post.ID) {
-					continue
-				}
-
-				log.Printf("New unseen post in r/%s: %s", subreddit, post.Title)
-				rememberPost(post.ID)
-
-				// Add to Redis stream
-				_, err := redisClient.XAdd(ctx, &redis.XAddArgs{
-					Stream: "reddit-events",
-					Values: map[string]interface{}{
-						"id":        post.ID,
-						"title":     post.Title,
-						"body":      post.Body,
-						"url":       post.URL,
-						"author":    post.Author,
-						"subreddit": post.SubredditName,
-						"created":   post.Created.Time.Format(time.RFC3339),
-					},
-				}).Result()
-
-				if err != nil {
-					log.Printf("Redis push failed: %v", err)
-					recordError("Redis push failed: " + err.Error())
-					appState.Lock()
-					appState.redisConnected = false
-					appState.Unlock()
-				} else {
-					log.Printf("Post pushed to Redis.")
-					appState.Lock()
-					appState.redisConnected = true
-					appState.messageCount++
-					appState.Unlock()
-				}
-			}
+	// The rate limiter's bucket lives in Redis, so every replica sharing this
+	// Redis instance draws from the same REDDIT_MAX_QPM budget.
+	maxQPM := defaultRedditMaxQPM
+	if raw := os.Getenv("REDDIT_MAX_QPM"); raw != "" {
+		if parsed, err := strconv.Atoi(raw); err == nil {
+			maxQPM = parsed
+		} else {
+			log.Printf("Invalid REDDIT_MAX_QPM %q, using default: %v", raw, err)
 		}
+	}
+	limiter := newRedditLimiter(redisClient, redditAppName, maxQPM)
+	health.SetLimiter(limiter)
+
+	poller := newPollerService(subreddits, client.Subreddit, sinks, seenStore, streamBatcher, limiter)
 
-		// Wait before next polling cycle, using calculated sleep time based on rate limits
-		time.Sleep(sleepTime)
+	if err := health.Start(rootCtx); err != nil {
+		log.Fatalf("Failed to start health server: %v", err)
 	}
-}
\ No newline at end of file
+	log.Printf("Health check server started on port %s", healthPort)
+
+	// supervisePoller runs until rootCtx is cancelled (by a shutdown signal)
+	// or the poller hits a fatal Reddit error; it restarts transient
+	// failures with backoff in between.
+	pollerDone := make(chan struct{})
+	go func() {
+		defer close(pollerDone)
+		supervisePoller(rootCtx, poller)
+	}()
+
+	<-rootCtx.Done()
+	log.Printf("Shutdown signal received, stopping services")
+
+	// Cancelling rootCtx already unwound the poller's run loop; wait for it
+	// to actually exit before closing the stream batcher, so Stop doesn't
+	// race with an in-flight Enqueue.
+	<-pollerDone
+	poller.Stop()
+
+	if err := health.Stop(); err != nil {
+		log.Printf("Health server shutdown error: %v", err)
+	}
+	health.Wait()
+
+	log.Printf("Shutdown complete")
+}
@@ -1,111 +1,95 @@
 package main
 
 import (
-	"container/list"
+	"context"
 	"testing"
 	"time"
 
+	"github.com/alicebob/miniredis/v2"
+	"github.com/go-redis/redis/v8"
 	"github.com/stretchr/testify/assert"
-	"github.com/stretchr/testify/mock"
+	"github.com/vartanbeno/go-reddit/v2/reddit"
 )
 
-func TestProcessPost(t *testing.T) {
-	// Reset the seen cache
-	seenPosts = make(map[string]*list.Element)
-	seenList = list.New()
-	
-	// Create a mock Redis streamer
-	mockRedis := new(MockRedisStreamer)
-	mockRedis.On("XAdd", mock.Anything, mock.Anything).Return("message-id", nil)
-	
-	// Create a test post
-	testPost := RedditPost{
-		ID:        "test1",
-		Title:     "Test Post",
-		Body:      "Test Body",
-		URL:       "https://example.com",
-		Author:    "testuser",
-		Subreddit: "testsubreddit",
-		Created:   time.Now(),
-	}
-	
-	// Process the post
-	err := processPost(mockRedis, testPost)
-	
-	// Verify no errors
-	assert.NoError(t, err)
-	
-	// Verify the post was added to Redis
-	mockRedis.AssertCalled(t, "XAdd", ctx, mock.MatchedBy(func(args *XAddArgs) bool {
-		return args.Stream == "reddit-events" && 
-			args.Values["id"] == testPost.ID &&
-			args.Values["title"] == testPost.Title
-	}))
-	
-	// Verify the post was marked as seen
-	assert.True(t, hasSeen(testPost.ID))
-	
-	// Process the same post again
-	mockRedis.On("XAdd", mock.Anything, mock.Anything).Return("message-id-2", nil)
-	err = processPost(mockRedis, testPost)
-	
-	// Verify no errors
-	assert.NoError(t, err)
-	
-	// Verify XAdd was NOT called a second time (post was already seen)
-	mockRedis.AssertNumberOfCalls(t, "XAdd", 1)
+// TestPublishPostDoesNotDoubleCountMessages drives a single publishPost call
+// through the real redisStreamSink/StreamBatcher pair (pipelining enabled,
+// matching the default config) and asserts messageCount only goes up by 1.
+// publishPost used to also bump messageCount itself whenever Publish
+// returned nil, double-counting every post on top of the sink's own
+// (necessarily asynchronous) bookkeeping.
+func TestPublishPostDoesNotDoubleCountMessages(t *testing.T) {
+	mr := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	defer client.Close()
+
+	// A long window means publishPost's call can't have flushed yet when it
+	// returns; Close below forces the flush and blocks until reportResult
+	// has actually run, so the assertion after it is deterministic rather
+	// than racing the batcher's goroutine.
+	batcher := NewStreamBatcher(client, time.Hour, 128)
+
+	sinks := []Sink{newRedisStreamSink(client, batcher)}
+
+	created := reddit.Timestamp{Time: time.Now()}
+	post := &reddit.Post{ID: "post1", Title: "Test", SubredditName: "wallstreetbets", Created: &created}
+
+	appState.Lock()
+	before := appState.messageCount
+	appState.Unlock()
+
+	publishPost(context.Background(), sinks, post)
+	batcher.Close()
+
+	appState.RLock()
+	defer appState.RUnlock()
+	assert.Equal(t, before+1, appState.messageCount, "messageCount should go up by exactly 1 per published post")
 }
 
-// Test that the LRU cache functionality works correctly
-func TestLRUCache(t *testing.T) {
-	// Reset global variables before test
-	seenPosts = make(map[string]*list.Element)
-	seenList = list.New()
-	
-	// Test hasSeen for non-existent post
-	assert.False(t, hasSeen("post1"))
-	
-	// Test rememberPost and hasSeen
-	rememberPost("post1")
-	assert.True(t, hasSeen("post1"))
-	
-	// Test that rememberPost moves existing items to front
-	rememberPost("post2")
-	rememberPost("post3")
-	assert.Equal(t, "post3", seenList.Front().Value.(string))
-	
-	rememberPost("post1") // This should move post1 to the front
-	assert.Equal(t, "post1", seenList.Front().Value.(string))
+// Test that the in-memory LRU store works correctly
+func TestMemorySeenStoreLRU(t *testing.T) {
+	ctx := context.Background()
+	seen := newMemorySeenStore(seenLimit)
+
+	// Test Has for non-existent post
+	assert.False(t, seen.Has(ctx, "post1"))
+
+	// Test Remember and Has
+	seen.Remember(ctx, "post1")
+	assert.True(t, seen.Has(ctx, "post1"))
+
+	// Test that Remember moves existing items to front
+	seen.Remember(ctx, "post2")
+	seen.Remember(ctx, "post3")
+	assert.Equal(t, "post3", seen.order.Front().Value.(string))
+
+	seen.Remember(ctx, "post1") // This should move post1 to the front
+	assert.Equal(t, "post1", seen.order.Front().Value.(string))
 }
 
-// TestLRUEviction tests the LRU eviction logic directly
-func TestLRUEviction(t *testing.T) {
-	// Since we can't modify seenLimit (it's a constant), we'll test the eviction
-	// logic by adding more than seenLimit items and checking the result
-	
-	// Reset global variables before test
-	seenPosts = make(map[string]*list.Element)
-	seenList = list.New()
-	
+// TestMemorySeenStoreEviction tests the LRU eviction logic directly
+func TestMemorySeenStoreEviction(t *testing.T) {
+	ctx := context.Background()
+	seen := newMemorySeenStore(seenLimit)
+
 	// Add posts up to the limit
 	for i := 0; i < seenLimit; i++ {
-		rememberPost(string(rune('a' + i % 26)) + string(rune('0' + i / 26)))
+		seen.Remember(ctx, string(rune('a'+i%26))+string(rune('0'+i/26)))
 	}
-	
+
 	// Verify we have exactly seenLimit items
-	assert.Equal(t, seenLimit, seenList.Len())
-	assert.Equal(t, seenLimit, len(seenPosts))
-	
+	assert.Equal(t, seenLimit, seen.order.Len())
+	assert.Equal(t, seenLimit, len(seen.ids))
+
 	// Remember one more post to trigger eviction
-	rememberPost("extra_post")
-	
+	seen.Remember(ctx, "extra_post")
+
 	// We should still have exactly seenLimit items (one was evicted)
-	assert.Equal(t, seenLimit, seenList.Len())
-	assert.Equal(t, seenLimit, len(seenPosts))
-	
+	assert.Equal(t, seenLimit, seen.order.Len())
+	assert.Equal(t, seenLimit, len(seen.ids))
+
 	// The first post ("a0") should have been evicted
-	assert.False(t, hasSeen("a0"))
-	
+	assert.False(t, seen.Has(ctx, "a0"))
+
 	// The extra post should be at the front
-	assert.Equal(t, "extra_post", seenList.Front().Value.(string))
-}
\ No newline at end of file
+	assert.Equal(t, "extra_post", seen.order.Front().Value.(string))
+}
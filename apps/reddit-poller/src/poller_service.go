@@ -0,0 +1,178 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/vartanbeno/go-reddit/v2/reddit"
+)
+
+// redditAuthError marks a poll failure as an authentication problem (Reddit
+// returned 401/403) rather than an ordinary network or rate-limit hiccup, so
+// the supervisor can decide whether to restart or give up.
+type redditAuthError struct {
+	subreddit string
+	status    int
+}
+
+func (e *redditAuthError) Error() string {
+	return fmt.Sprintf("reddit auth error fetching r/%s (status %d)", e.subreddit, e.status)
+}
+
+// redditSubredditService is the subset of *reddit.Client.Subreddit the
+// polling loop needs, so it can be driven by a mock in tests instead of a
+// real Reddit client.
+type redditSubredditService interface {
+	NewPosts(ctx context.Context, subreddit string, opts *reddit.ListOptions) ([]*reddit.Post, *reddit.Response, error)
+}
+
+// PollerService owns the Reddit polling loop. It implements Service so main
+// can start, gracefully stop and supervise it like any other long-running
+// component.
+type PollerService struct {
+	subreddits    []string
+	client        redditSubredditService
+	sinks         []Sink
+	seenStore     SeenStore
+	streamBatcher *StreamBatcher
+	limiter       *RedditLimiter
+
+	cancel context.CancelFunc
+	done   chan struct{}
+	err    error
+}
+
+func newPollerService(subreddits []string, client redditSubredditService, sinks []Sink, seenStore SeenStore, streamBatcher *StreamBatcher, limiter *RedditLimiter) *PollerService {
+	return &PollerService{
+		subreddits:    subreddits,
+		client:        client,
+		sinks:         sinks,
+		seenStore:     seenStore,
+		streamBatcher: streamBatcher,
+		limiter:       limiter,
+	}
+}
+
+func (p *PollerService) Start(ctx context.Context) error {
+	runCtx, cancel := context.WithCancel(ctx)
+	p.cancel = cancel
+	p.done = make(chan struct{})
+
+	go func() {
+		defer close(p.done)
+		p.err = p.run(runCtx)
+	}()
+
+	return nil
+}
+
+// Stop asks the polling loop to wind down and flushes any writes the stream
+// batcher still has buffered. It does not close the Redis client; that
+// belongs to whoever constructed it, since it's shared with the seen store
+// and watcher dispatch.
+func (p *PollerService) Stop() error {
+	if p.cancel != nil {
+		p.cancel()
+	}
+	if p.streamBatcher != nil {
+		p.streamBatcher.Close()
+	}
+	return nil
+}
+
+func (p *PollerService) Wait() {
+	if p.done != nil {
+		<-p.done
+	}
+}
+
+// Err returns the error the last run exited with. It is only meaningful
+// after Wait returns, and is nil after a clean shutdown.
+func (p *PollerService) Err() error {
+	return p.err
+}
+
+// run executes the polling loop until ctx is cancelled or a Reddit auth
+// error is encountered, in which case it returns that error so the
+// supervisor can decide whether to restart.
+func (p *PollerService) run(ctx context.Context) error {
+	for {
+		sleepTime := 1 * time.Second // Default wait time
+
+		for _, subreddit := range p.subreddits {
+			if p.limiter != nil {
+				if err := p.limiter.Wait(ctx); err != nil {
+					if ctx.Err() != nil {
+						return nil // shutting down
+					}
+					log.Printf("Rate limiter wait failed, proceeding without throttling: %v", err)
+				}
+			}
+
+			posts, resp, err := p.client.NewPosts(ctx, subreddit, &reddit.ListOptions{Limit: 10})
+
+			// Check rate limits and handle them appropriately
+			if resp != nil {
+				remaining, _ := strconv.Atoi(resp.Header.Get("X-Ratelimit-Remaining"))
+				resetTime, _ := strconv.Atoi(resp.Header.Get("X-Ratelimit-Reset"))
+				used, _ := strconv.Atoi(resp.Header.Get("X-Ratelimit-Used"))
+
+				log.Printf("Rate limits for %s: %d used, %d remaining, %d seconds until reset",
+					subreddit, used, remaining, resetTime)
+
+				if p.limiter != nil {
+					p.limiter.SyncFromHeaders(ctx, remaining, resetTime)
+				}
+
+				// If we're getting close to the limit, respect the reset time
+				if remaining < 10 {
+					log.Printf("Approaching rate limit! Slowing down for %d seconds", resetTime)
+					sleepTime = time.Duration(resetTime) * time.Second
+				}
+			}
+
+			if err != nil {
+				log.Printf("Failed to fetch posts from %s: %v", subreddit, err)
+				recordError(fmt.Sprintf("Failed to fetch posts from %s: %v", subreddit, err.Error()))
+				appState.Lock()
+				appState.redditConnected = false
+				appState.Unlock()
+
+				if resp != nil && (resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == http.StatusForbidden) {
+					return &redditAuthError{subreddit: subreddit, status: resp.StatusCode}
+				}
+				continue // Skip to next subreddit
+			}
+
+			// Update Reddit connection status and last successful poll time
+			appState.Lock()
+			appState.redditConnected = true
+			appState.lastSuccessfulPoll = time.Now()
+			appState.Unlock()
+
+			for _, post := range posts {
+				// Skip if we've already seen this post
+				if p.seenStore.Has(ctx, post.ID) {
+					continue
+				}
+
+				log.Printf("New unseen post in r/%s: %s", subreddit, post.Title)
+				p.seenStore.Remember(ctx, post.ID)
+
+				publishPost(ctx, p.sinks, post)
+			}
+		}
+
+		// Wait before next polling cycle, using calculated sleep time based
+		// on rate limits, but wake up immediately if asked to shut down.
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(sleepTime):
+		}
+	}
+}
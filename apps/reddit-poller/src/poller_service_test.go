@@ -0,0 +1,105 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"github.com/vartanbeno/go-reddit/v2/reddit"
+)
+
+// These tests drive PollerService.run directly, the way the shipped binary
+// actually calls it (via supervisePoller).
+
+func TestPollerServiceRunPublishesNewPosts(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel() // single pass: run should exit via ctx.Done() right after polling once
+
+	created := reddit.Timestamp{Time: time.Now()}
+	posts := []*reddit.Post{
+		{ID: "post1", Title: "Test Post 1", SubredditName: "wallstreetbets", Created: &created},
+	}
+
+	mockSubreddit := new(MockSubreddit)
+	mockSubreddit.On("NewPosts", mock.Anything, "wallstreetbets", mock.Anything).
+		Return(posts, &reddit.Response{Response: &http.Response{StatusCode: http.StatusOK, Header: http.Header{}}}, nil)
+
+	mockRedis := new(MockRedisStreamer)
+	mockRedis.On("XAdd", mock.Anything, mock.Anything).Return("message-id", nil)
+	sinks := []Sink{newStreamSink(mockRedis)}
+
+	seen := newMemorySeenStore(seenLimit)
+	poller := newPollerService([]string{"wallstreetbets"}, mockSubreddit, sinks, seen, nil, nil)
+
+	require.NoError(t, poller.run(ctx))
+
+	mockSubreddit.AssertNumberOfCalls(t, "NewPosts", 1)
+	mockRedis.AssertNumberOfCalls(t, "XAdd", 1)
+	assert.True(t, seen.Has(context.Background(), "post1"))
+}
+
+func TestPollerServiceRunSkipsAlreadySeenPosts(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	created := reddit.Timestamp{Time: time.Now()}
+	posts := []*reddit.Post{
+		{ID: "post1", Title: "Already seen", SubredditName: "wallstreetbets", Created: &created},
+	}
+
+	mockSubreddit := new(MockSubreddit)
+	mockSubreddit.On("NewPosts", mock.Anything, "wallstreetbets", mock.Anything).
+		Return(posts, &reddit.Response{Response: &http.Response{StatusCode: http.StatusOK, Header: http.Header{}}}, nil)
+
+	mockRedis := new(MockRedisStreamer)
+	sinks := []Sink{newStreamSink(mockRedis)}
+
+	seen := newMemorySeenStore(seenLimit)
+	seen.Remember(context.Background(), "post1")
+	poller := newPollerService([]string{"wallstreetbets"}, mockSubreddit, sinks, seen, nil, nil)
+
+	require.NoError(t, poller.run(ctx))
+
+	mockRedis.AssertNumberOfCalls(t, "XAdd", 0)
+}
+
+func TestPollerServiceRunReturnsAuthErrorOnUnauthorized(t *testing.T) {
+	ctx := context.Background()
+
+	mockSubreddit := new(MockSubreddit)
+	mockSubreddit.On("NewPosts", mock.Anything, "wallstreetbets", mock.Anything).
+		Return([]*reddit.Post(nil), &reddit.Response{Response: &http.Response{StatusCode: http.StatusUnauthorized, Header: http.Header{}}}, errors.New("401 unauthorized"))
+
+	seen := newMemorySeenStore(seenLimit)
+	poller := newPollerService([]string{"wallstreetbets"}, mockSubreddit, nil, seen, nil, nil)
+
+	err := poller.run(ctx)
+
+	var authErr *redditAuthError
+	require.ErrorAs(t, err, &authErr)
+	assert.Equal(t, "wallstreetbets", authErr.subreddit)
+	assert.Equal(t, http.StatusUnauthorized, authErr.status)
+}
+
+func TestPollerServiceRunContinuesPastTransientFetchError(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	mockSubreddit := new(MockSubreddit)
+	mockSubreddit.On("NewPosts", mock.Anything, "wallstreetbets", mock.Anything).
+		Return([]*reddit.Post(nil), (*reddit.Response)(nil), errors.New("connection reset"))
+	mockSubreddit.On("NewPosts", mock.Anything, "pennystocks", mock.Anything).
+		Return([]*reddit.Post(nil), &reddit.Response{Response: &http.Response{StatusCode: http.StatusOK, Header: http.Header{}}}, nil)
+
+	seen := newMemorySeenStore(seenLimit)
+	poller := newPollerService([]string{"wallstreetbets", "pennystocks"}, mockSubreddit, nil, seen, nil, nil)
+
+	require.NoError(t, poller.run(ctx))
+
+	mockSubreddit.AssertNumberOfCalls(t, "NewPosts", 2)
+}
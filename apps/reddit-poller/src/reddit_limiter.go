@@ -0,0 +1,155 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math/rand"
+	"sync"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+const defaultRedditMaxQPM = 60
+
+// redditLimiterScript atomically reads, lazily initializes and decrements a
+// per-minute token bucket. It returns the remaining token count after the
+// decrement, or -1 if the bucket was already empty, so callers never need a
+// separate GET before deciding whether to wait.
+const redditLimiterScript = `
+local current = redis.call("GET", KEYS[1])
+if current == false then
+	redis.call("SET", KEYS[1], ARGV[1], "EX", ARGV[2])
+	current = tonumber(ARGV[1])
+else
+	current = tonumber(current)
+end
+if current <= 0 then
+	return -1
+end
+redis.call("DECR", KEYS[1])
+return current - 1
+`
+
+// redditLimiterSyncScript writes the shared Redis bucket to match Reddit's
+// authoritative remaining count from the X-Ratelimit-Remaining header. It
+// never raises the bucket above whatever value is already there: another
+// replica's own Wait calls may have decremented it further in the meantime,
+// and a stale, larger header value shouldn't hand back tokens those calls
+// already correctly spent.
+const redditLimiterSyncScript = `
+local current = redis.call("GET", KEYS[1])
+local newVal = tonumber(ARGV[1])
+if current ~= false and tonumber(current) < newVal then
+	newVal = tonumber(current)
+end
+redis.call("SET", KEYS[1], newVal, "EX", ARGV[2])
+return newVal
+`
+
+// RedditLimiter is a cluster-safe token-bucket rate limiter for Reddit API
+// calls: the bucket itself lives in Redis (shared by every poller replica),
+// while the locally mirrored remaining/resetAt is updated both from our own
+// Wait calls and from Reddit's X-Ratelimit-* response headers, so /health
+// reports what Reddit itself last told us.
+type RedditLimiter struct {
+	client  *redis.Client
+	appName string
+	maxQPM  int
+
+	mu        sync.Mutex
+	remaining int
+	resetAt   time.Time
+}
+
+func newRedditLimiter(client *redis.Client, appName string, maxQPM int) *RedditLimiter {
+	if maxQPM <= 0 {
+		maxQPM = defaultRedditMaxQPM
+	}
+	return &RedditLimiter{
+		client:    client,
+		appName:   appName,
+		maxQPM:    maxQPM,
+		remaining: maxQPM,
+	}
+}
+
+func (l *RedditLimiter) bucketKey(t time.Time) string {
+	return fmt.Sprintf("reddit:limiter:%s:%d", l.appName, t.Unix()/60)
+}
+
+// Wait blocks until a token is available in the current minute's Redis
+// bucket, so multiple replicas sharing the same Redis instance collectively
+// stay within REDDIT_MAX_QPM. If Redis itself errors, Wait gives up and
+// returns the error rather than blocking forever; callers should proceed
+// without throttling in that case rather than stall the whole poller.
+func (l *RedditLimiter) Wait(ctx context.Context) error {
+	for {
+		now := time.Now()
+		windowEnd := now.Truncate(time.Minute).Add(time.Minute)
+		ttl := int(time.Until(windowEnd).Seconds()) + 1
+
+		res, err := l.client.Eval(ctx, redditLimiterScript, []string{l.bucketKey(now)}, l.maxQPM, ttl).Result()
+		if err != nil {
+			return err
+		}
+
+		remaining, ok := res.(int64)
+		if !ok {
+			return fmt.Errorf("reddit limiter: unexpected script result %v", res)
+		}
+
+		if remaining >= 0 {
+			l.setState(int(remaining), windowEnd)
+			return nil
+		}
+
+		sleep := time.Until(windowEnd)
+		if sleep <= 0 {
+			sleep = time.Second
+		}
+		sleep += time.Duration(rand.Int63n(int64(time.Second)))
+
+		log.Printf("Reddit rate limit bucket exhausted, waiting %s for next window", sleep)
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(sleep):
+		}
+	}
+}
+
+// SyncFromHeaders reconciles both the locally mirrored bucket state and the
+// shared Redis bucket with Reddit's own X-Ratelimit-Remaining/X-Ratelimit-Reset
+// response headers, which is the most authoritative view of our app's quota.
+// Without writing the Redis bucket too, it would keep drifting from Reddit's
+// own count, since Wait would otherwise only ever be driven by each
+// replica's local decrements. Sync errors are logged rather than returned;
+// callers shouldn't fail a poll over a best-effort reconciliation.
+func (l *RedditLimiter) SyncFromHeaders(ctx context.Context, remaining int, resetSeconds int) {
+	now := time.Now()
+	l.setState(remaining, now.Add(time.Duration(resetSeconds)*time.Second))
+
+	windowEnd := now.Truncate(time.Minute).Add(time.Minute)
+	ttl := int(time.Until(windowEnd).Seconds()) + 1
+	if err := l.client.Eval(ctx, redditLimiterSyncScript, []string{l.bucketKey(now)}, remaining, ttl).Err(); err != nil {
+		log.Printf("Reddit limiter: failed to sync Redis bucket from headers: %v", err)
+	}
+}
+
+func (l *RedditLimiter) setState(remaining int, resetAt time.Time) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.remaining = remaining
+	l.resetAt = resetAt
+}
+
+// State returns the last known remaining tokens and reset time, for
+// reporting on /health.
+func (l *RedditLimiter) State() (remaining int, resetAt time.Time) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.remaining, l.resetAt
+}
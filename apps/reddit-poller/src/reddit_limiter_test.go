@@ -0,0 +1,137 @@
+package main
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/go-redis/redis/v8"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRedditLimiterBlocksOnceBudgetExhausted(t *testing.T) {
+	ctx := context.Background()
+	mr := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	defer client.Close()
+
+	limiter := newRedditLimiter(client, "test-app", 2)
+
+	require.NoError(t, limiter.Wait(ctx))
+	require.NoError(t, limiter.Wait(ctx))
+
+	remaining, _ := limiter.State()
+	assert.Equal(t, 0, remaining)
+
+	// The budget is exhausted for the current minute; a third Wait should
+	// block rather than hand out a token early.
+	waitCtx, cancel := context.WithTimeout(ctx, 200*time.Millisecond)
+	defer cancel()
+	err := limiter.Wait(waitCtx)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+}
+
+// TestRedditLimiterSharedAcrossReplicas asserts two poller instances that
+// share the same Redis instance draw from one combined budget instead of
+// each getting their own REDDIT_MAX_QPM allowance.
+func TestRedditLimiterSharedAcrossReplicas(t *testing.T) {
+	ctx := context.Background()
+	mr := miniredis.RunT(t)
+
+	const maxQPM = 10
+	client1 := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	defer client1.Close()
+	client2 := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	defer client2.Close()
+
+	limiterA := newRedditLimiter(client1, "shared-app", maxQPM)
+	limiterB := newRedditLimiter(client2, "shared-app", maxQPM)
+
+	var mu sync.Mutex
+	granted := 0
+	grant := func(limiter *RedditLimiter) {
+		timeoutCtx, cancel := context.WithTimeout(ctx, 50*time.Millisecond)
+		defer cancel()
+		if err := limiter.Wait(timeoutCtx); err == nil {
+			mu.Lock()
+			granted++
+			mu.Unlock()
+		}
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < maxQPM+5; i++ {
+		wg.Add(2)
+		go func() { defer wg.Done(); grant(limiterA) }()
+		go func() { defer wg.Done(); grant(limiterB) }()
+	}
+	wg.Wait()
+
+	assert.LessOrEqual(t, granted, maxQPM)
+}
+
+func TestRedditLimiterSyncFromHeaders(t *testing.T) {
+	ctx := context.Background()
+	mr := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	defer client.Close()
+
+	limiter := newRedditLimiter(client, "test-app", 60)
+	limiter.SyncFromHeaders(ctx, 5, 30)
+
+	remaining, resetAt := limiter.State()
+	assert.Equal(t, 5, remaining)
+	assert.WithinDuration(t, time.Now().Add(30*time.Second), resetAt, 2*time.Second)
+}
+
+// TestRedditLimiterSyncFromHeadersUpdatesSharedBucket asserts that syncing
+// from headers doesn't just update the local mirror: it writes the shared
+// Redis bucket too, so a second replica's Wait call sees Reddit's
+// authoritative remaining count instead of the bucket's own decrement-only
+// history.
+func TestRedditLimiterSyncFromHeadersUpdatesSharedBucket(t *testing.T) {
+	ctx := context.Background()
+	mr := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	defer client.Close()
+
+	limiterA := newRedditLimiter(client, "shared-app", 60)
+	limiterB := newRedditLimiter(client, "shared-app", 60)
+
+	// Reddit reports only 1 token left for the app, well below what
+	// limiterA's own local decrements would suggest.
+	limiterA.SyncFromHeaders(ctx, 1, 30)
+
+	require.NoError(t, limiterB.Wait(ctx))
+
+	waitCtx, cancel := context.WithTimeout(ctx, 200*time.Millisecond)
+	defer cancel()
+	assert.ErrorIs(t, limiterB.Wait(waitCtx), context.DeadlineExceeded)
+}
+
+// TestRedditLimiterSyncFromHeadersDoesNotRaiseBucket asserts a stale, larger
+// header value can't hand back tokens another replica's Wait calls already
+// spent from the shared bucket.
+func TestRedditLimiterSyncFromHeadersDoesNotRaiseBucket(t *testing.T) {
+	ctx := context.Background()
+	mr := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	defer client.Close()
+
+	limiter := newRedditLimiter(client, "shared-app", 10)
+
+	// Drain the bucket down to 0 via real Wait calls.
+	for i := 0; i < 10; i++ {
+		require.NoError(t, limiter.Wait(ctx))
+	}
+
+	// A stale/delayed header claiming 5 remaining shouldn't undo that.
+	limiter.SyncFromHeaders(ctx, 5, 30)
+
+	waitCtx, cancel := context.WithTimeout(ctx, 200*time.Millisecond)
+	defer cancel()
+	assert.ErrorIs(t, limiter.Wait(waitCtx), context.DeadlineExceeded)
+}
@@ -2,7 +2,6 @@ package main
 
 import (
 	"context"
-	"log"
 	"time"
 )
 
@@ -12,44 +11,20 @@ type RedisStreamer interface {
 	XAdd(ctx context.Context, args *XAddArgs) (string, error)
 }
 
+// watcherEvaluator and watcherDispatch are consulted from publishPost when
+// set up by main. Left nil, watcher evaluation is a no-op, which keeps
+// publishPost unit-testable without a registry or Postgres.
+var (
+	watcherEvaluator WatcherEvaluator
+	watcherDispatch  *watcherDispatcher
+)
+
 // XAddArgs holds the arguments for the XAdd operation
 type XAddArgs struct {
 	Stream string
 	Values map[string]interface{}
 }
 
-// processPost processes a single Reddit post and adds it to Redis if it's new
-func processPost(streamer RedisStreamer, post RedditPost) error {
-	if hasSeen(post.ID) {
-		return nil // Already processed
-	}
-
-	log.Printf("New unseen post: %s", post.Title)
-	rememberPost(post.ID)
-
-	// Add to Redis stream
-	_, err := streamer.XAdd(ctx, &XAddArgs{
-		Stream: "reddit-events",
-		Values: map[string]interface{}{
-			"id":        post.ID,
-			"title":     post.Title,
-			"body":      post.Body,
-			"url":       post.URL,
-			"author":    post.Author,
-			"subreddit": post.Subreddit,
-			"created":   post.Created.Format(time.RFC3339),
-		},
-	})
-
-	if err != nil {
-		log.Printf("Redis push failed: %v", err)
-		return err
-	}
-
-	log.Printf("Post pushed to Redis.")
-	return nil
-}
-
 // RedditPost represents a Reddit post
 type RedditPost struct {
 	ID        string
@@ -59,4 +34,4 @@ type RedditPost struct {
 	Author    string
 	Subreddit string
 	Created   time.Time
-}
\ No newline at end of file
+}
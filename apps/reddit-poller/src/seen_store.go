@@ -0,0 +1,182 @@
+package main
+
+import (
+	"container/list"
+	"context"
+	"database/sql"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// SeenStore tracks which post IDs have already been emitted so the poller
+// does not push duplicates to Redis. Implementations may or may not
+// survive a process restart; see memorySeenStore, redisSeenStore and
+// postgresSeenStore.
+type SeenStore interface {
+	Has(ctx context.Context, id string) bool
+	Remember(ctx context.Context, id string)
+}
+
+// newSeenStore builds a SeenStore from the SEEN_STORE environment variable
+// ("memory", "redis" or "postgres"). It defaults to "memory" to preserve
+// the historical behavior when SEEN_STORE is unset.
+func newSeenStore(kind string, redisClient *redis.Client, db *sql.DB) SeenStore {
+	switch kind {
+	case "redis":
+		return newRedisSeenStore(redisClient, "reddit-poller:seen", seenLimit)
+	case "postgres":
+		return newPostgresSeenStore(db, seenLimit, 5*time.Minute)
+	default:
+		return newMemorySeenStore(seenLimit)
+	}
+}
+
+// memorySeenStore is the original in-process LRU. It loses its state on
+// restart, which is why redisSeenStore and postgresSeenStore exist.
+type memorySeenStore struct {
+	mu    sync.Mutex
+	limit int
+	ids   map[string]*list.Element
+	order *list.List // front = newest, back = oldest
+}
+
+func newMemorySeenStore(limit int) *memorySeenStore {
+	return &memorySeenStore{
+		limit: limit,
+		ids:   make(map[string]*list.Element),
+		order: list.New(),
+	}
+}
+
+func (s *memorySeenStore) Has(ctx context.Context, id string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_, exists := s.ids[id]
+	return exists
+}
+
+func (s *memorySeenStore) Remember(ctx context.Context, id string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if elem, exists := s.ids[id]; exists {
+		s.order.MoveToFront(elem)
+		return
+	}
+
+	elem := s.order.PushFront(id)
+	s.ids[id] = elem
+
+	if s.order.Len() > s.limit {
+		tail := s.order.Back()
+		if tail != nil {
+			delete(s.ids, tail.Value.(string))
+			s.order.Remove(tail)
+		}
+	}
+}
+
+// redisSeenStore persists seen IDs in a Redis sorted set keyed by insertion
+// time, so state survives a poller restart. The set is capped at limit
+// entries by trimming the lowest-scored (oldest) members after each insert.
+type redisSeenStore struct {
+	client *redis.Client
+	key    string
+	limit  int64
+}
+
+func newRedisSeenStore(client *redis.Client, key string, limit int) *redisSeenStore {
+	return &redisSeenStore{client: client, key: key, limit: int64(limit)}
+}
+
+func (s *redisSeenStore) Has(ctx context.Context, id string) bool {
+	_, err := s.client.ZScore(ctx, s.key, id).Result()
+	if err == redis.Nil {
+		return false
+	}
+	if err != nil {
+		log.Printf("redisSeenStore: ZSCORE failed: %v", err)
+		return false
+	}
+	return true
+}
+
+func (s *redisSeenStore) Remember(ctx context.Context, id string) {
+	if err := s.client.ZAdd(ctx, s.key, &redis.Z{
+		Score:  float64(time.Now().UnixNano()),
+		Member: id,
+	}).Err(); err != nil {
+		log.Printf("redisSeenStore: ZADD failed: %v", err)
+		return
+	}
+
+	// Keep only the most recent `limit` members: rank 0 is the oldest, so
+	// trim everything below the last `limit` ranks.
+	if err := s.client.ZRemRangeByRank(ctx, s.key, 0, -s.limit-1).Err(); err != nil {
+		log.Printf("redisSeenStore: ZREMRANGEBYRANK failed: %v", err)
+	}
+}
+
+// postgresSeenStore persists seen IDs in Postgres so state survives both
+// restarts and pod rescheduling. A background goroutine periodically
+// prunes rows older than the retention window implied by limit.
+type postgresSeenStore struct {
+	db            *sql.DB
+	limit         int
+	pruneInterval time.Duration
+}
+
+func newPostgresSeenStore(db *sql.DB, limit int, pruneInterval time.Duration) *postgresSeenStore {
+	s := &postgresSeenStore{db: db, limit: limit, pruneInterval: pruneInterval}
+	if pruneInterval > 0 {
+		go s.prunePeriodically()
+	}
+	return s
+}
+
+func (s *postgresSeenStore) Has(ctx context.Context, id string) bool {
+	var exists bool
+	err := s.db.QueryRowContext(ctx, `SELECT EXISTS(SELECT 1 FROM seen_posts WHERE id = $1)`, id).Scan(&exists)
+	if err != nil {
+		log.Printf("postgresSeenStore: lookup failed: %v", err)
+		return false
+	}
+	return exists
+}
+
+func (s *postgresSeenStore) Remember(ctx context.Context, id string) {
+	_, err := s.db.ExecContext(ctx, `
+		INSERT INTO seen_posts (id, seen_at) VALUES ($1, $2)
+		ON CONFLICT (id) DO UPDATE SET seen_at = EXCLUDED.seen_at`,
+		id, time.Now().UTC())
+	if err != nil {
+		log.Printf("postgresSeenStore: upsert failed: %v", err)
+	}
+}
+
+// prunePeriodically keeps the seen_posts table bounded to roughly `limit`
+// rows by deleting the oldest entries beyond it.
+func (s *postgresSeenStore) prunePeriodically() {
+	ticker := time.NewTicker(s.pruneInterval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		if err := s.prune(); err != nil {
+			log.Printf("postgresSeenStore: prune failed: %v", err)
+		}
+	}
+}
+
+func (s *postgresSeenStore) prune() error {
+	_, err := s.db.ExecContext(context.Background(), `
+		DELETE FROM seen_posts
+		WHERE id IN (
+			SELECT id FROM seen_posts
+			ORDER BY seen_at DESC
+			OFFSET $1
+		)`, s.limit)
+	return err
+}
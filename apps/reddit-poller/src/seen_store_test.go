@@ -0,0 +1,71 @@
+package main
+
+import (
+	"context"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/alicebob/miniredis/v2"
+	"github.com/go-redis/redis/v8"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMemorySeenStore(t *testing.T) {
+	ctx := context.Background()
+	store := newMemorySeenStore(2)
+
+	assert.False(t, store.Has(ctx, "a"))
+	store.Remember(ctx, "a")
+	store.Remember(ctx, "b")
+	assert.True(t, store.Has(ctx, "a"))
+	assert.True(t, store.Has(ctx, "b"))
+
+	// Exceeding the limit evicts the oldest entry.
+	store.Remember(ctx, "c")
+	assert.False(t, store.Has(ctx, "a"))
+	assert.True(t, store.Has(ctx, "b"))
+	assert.True(t, store.Has(ctx, "c"))
+}
+
+func TestRedisSeenStore(t *testing.T) {
+	ctx := context.Background()
+	mr := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	defer client.Close()
+
+	store := newRedisSeenStore(client, "test:seen", 2)
+
+	assert.False(t, store.Has(ctx, "post1"))
+	store.Remember(ctx, "post1")
+	assert.True(t, store.Has(ctx, "post1"))
+
+	// Exceeding the limit trims the oldest member from the sorted set.
+	store.Remember(ctx, "post2")
+	store.Remember(ctx, "post3")
+	assert.False(t, store.Has(ctx, "post1"))
+	assert.True(t, store.Has(ctx, "post2"))
+	assert.True(t, store.Has(ctx, "post3"))
+}
+
+func TestPostgresSeenStore(t *testing.T) {
+	ctx := context.Background()
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	store := newPostgresSeenStore(db, 500, 0)
+
+	mock.ExpectExec("INSERT INTO seen_posts").
+		WithArgs("post1", sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	store.Remember(ctx, "post1")
+
+	rows := sqlmock.NewRows([]string{"exists"}).AddRow(true)
+	mock.ExpectQuery("SELECT EXISTS").
+		WithArgs("post1").
+		WillReturnRows(rows)
+	assert.True(t, store.Has(ctx, "post1"))
+
+	require.NoError(t, mock.ExpectationsWereMet())
+}
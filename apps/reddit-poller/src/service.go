@@ -0,0 +1,81 @@
+package main
+
+import (
+	"context"
+	"log"
+	"strings"
+	"time"
+)
+
+// Service is implemented by the long-running components main supervises
+// (the health server and the poller). Start must return promptly, spawning
+// any background work in its own goroutine; Stop asks that work to wind
+// down, and Wait blocks until it has.
+type Service interface {
+	Start(ctx context.Context) error
+	Stop() error
+	Wait()
+}
+
+const (
+	restartBackoffBase = 1 * time.Second
+	restartBackoffMax  = 1 * time.Minute
+)
+
+// isFatalRedditError reports whether err indicates permanently invalid
+// Reddit credentials, as opposed to a transient auth hiccup (clock skew,
+// a momentarily expired token) worth retrying.
+func isFatalRedditError(err error) bool {
+	if err == nil {
+		return false
+	}
+	msg := strings.ToLower(err.Error())
+	return strings.Contains(msg, "invalid_grant") ||
+		strings.Contains(msg, "invalid_client") ||
+		strings.Contains(msg, "403")
+}
+
+// supervisePoller runs poller until ctx is cancelled, restarting it with
+// exponential backoff whenever it exits with a transient Reddit auth
+// error. A fatal error (bad credentials) or a clean shutdown stops the
+// supervisor instead of restarting.
+func supervisePoller(ctx context.Context, poller *PollerService) {
+	backoff := restartBackoffBase
+
+	for {
+		if err := poller.Start(ctx); err != nil {
+			log.Printf("Poller failed to start: %v", err)
+			return
+		}
+		poller.Wait()
+
+		if ctx.Err() != nil {
+			return // shutting down; not a failure
+		}
+
+		err := poller.Err()
+		if err == nil {
+			return
+		}
+
+		if isFatalRedditError(err) {
+			log.Printf("Poller stopped with a fatal Reddit error, not restarting: %v", err)
+			recordError("Poller stopped with a fatal Reddit error: " + err.Error())
+			return
+		}
+
+		log.Printf("Poller exited (%v); restarting in %s", err, backoff)
+		recordError("Poller restarting after transient error: " + err.Error())
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(backoff):
+		}
+
+		backoff *= 2
+		if backoff > restartBackoffMax {
+			backoff = restartBackoffMax
+		}
+	}
+}
@@ -0,0 +1,148 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// Sink is a destination processPost (and the poller's runtime loop) publish
+// new posts to. Sinks are composed into a slice so the same post can reach,
+// e.g., both a Redis Stream and a Pub/Sub channel without either knowing
+// about the other.
+type Sink interface {
+	Publish(ctx context.Context, post RedditPost) error
+}
+
+// streamSink publishes to the reddit-events Redis Stream via a
+// RedisStreamer, so it can be backed by the real client or a mock in tests.
+// It always issues a synchronous XAdd; see redisStreamSink for the
+// batching-aware production equivalent.
+type streamSink struct {
+	streamer RedisStreamer
+}
+
+func newStreamSink(streamer RedisStreamer) *streamSink {
+	return &streamSink{streamer: streamer}
+}
+
+func (s *streamSink) Publish(ctx context.Context, post RedditPost) error {
+	_, err := s.streamer.XAdd(ctx, &XAddArgs{
+		Stream: "reddit-events",
+		Values: map[string]interface{}{
+			"id":        post.ID,
+			"title":     post.Title,
+			"body":      post.Body,
+			"url":       post.URL,
+			"author":    post.Author,
+			"subreddit": post.Subreddit,
+			"created":   post.Created.Format(time.RFC3339),
+		},
+	})
+	if err != nil {
+		return err
+	}
+	log.Printf("Post pushed to Redis.")
+	return nil
+}
+
+// redisStreamSink is the production counterpart of streamSink: it routes
+// through a StreamBatcher when pipelining is enabled and falls back to a
+// direct XAdd otherwise.
+type redisStreamSink struct {
+	client  *redis.Client
+	batcher *StreamBatcher
+}
+
+func newRedisStreamSink(client *redis.Client, batcher *StreamBatcher) *redisStreamSink {
+	return &redisStreamSink{client: client, batcher: batcher}
+}
+
+// Publish always returns nil: both the direct and batched paths report their
+// outcome to appState/recordError themselves via reportResult, since the
+// batched path can't know its outcome before returning (see below). A caller
+// that tried to also do its own success/failure accounting on this sink's
+// return value would double-count every message.
+func (s *redisStreamSink) Publish(ctx context.Context, post RedditPost) error {
+	args := &redis.XAddArgs{
+		Stream: "reddit-events",
+		Values: map[string]interface{}{
+			"id":        post.ID,
+			"title":     post.Title,
+			"body":      post.Body,
+			"url":       post.URL,
+			"author":    post.Author,
+			"subreddit": post.Subreddit,
+			"created":   post.Created.Format(time.RFC3339),
+		},
+	}
+
+	if s.batcher == nil {
+		_, err := s.client.XAdd(ctx, args).Result()
+		s.reportResult(err)
+		return nil
+	}
+
+	// The poller calls Publish once per post, sequentially, so blocking here
+	// until the batch flushes would cap the effective batch size at 1 and
+	// turn pipelining into pure added latency. Enqueue and return
+	// immediately instead; the flush's outcome is reported via reportResult
+	// once it actually happens, which may well be after this call returns.
+	s.batcher.Enqueue(args, func(id string, err error) { s.reportResult(err) })
+	return nil
+}
+
+// reportResult records a flushed batch item's outcome in appState, since by
+// the time a batch flushes the Publish call that enqueued it has long since
+// returned and can no longer report it itself.
+func (s *redisStreamSink) reportResult(err error) {
+	if err != nil {
+		log.Printf("Redis push failed: %v", err)
+		recordError("Redis push failed: " + err.Error())
+		appState.Lock()
+		appState.redisConnected = false
+		appState.Unlock()
+		return
+	}
+
+	log.Printf("Post pushed to Redis.")
+	appState.Lock()
+	appState.redisConnected = true
+	appState.messageCount++
+	appState.Unlock()
+}
+
+// RedisPublisher is the subset of *redis.Client a pubsubSink needs to
+// publish a message, so it can be mocked in tests.
+type RedisPublisher interface {
+	Publish(ctx context.Context, channel string, message interface{}) *redis.IntCmd
+}
+
+// pubsubSink publishes each post's JSON encoding to a per-subreddit
+// reddit-events:<subreddit> channel, for subscribers that want lower-latency
+// fan-out than polling a Stream (e.g. PSUBSCRIBE reddit-events:*).
+type pubsubSink struct {
+	client RedisPublisher
+}
+
+func newPubsubSink(client RedisPublisher) *pubsubSink {
+	return &pubsubSink{client: client}
+}
+
+func (s *pubsubSink) Publish(ctx context.Context, post RedditPost) error {
+	payload, err := json.Marshal(post)
+	if err != nil {
+		return err
+	}
+
+	channel := "reddit-events:" + post.Subreddit
+	if err := s.client.Publish(ctx, channel, payload).Err(); err != nil {
+		return err
+	}
+
+	log.Printf("Post published to %s.", channel)
+	return nil
+}
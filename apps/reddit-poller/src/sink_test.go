@@ -0,0 +1,127 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/go-redis/redis/v8"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vartanbeno/go-reddit/v2/reddit"
+)
+
+func TestRedisStreamSinkPublish(t *testing.T) {
+	ctx := context.Background()
+	mr := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	defer client.Close()
+
+	sink := newRedisStreamSink(client, nil)
+	post := RedditPost{ID: "post1", Title: "Test", Subreddit: "wallstreetbets", Created: time.Now()}
+
+	require.NoError(t, sink.Publish(ctx, post))
+
+	length, err := client.XLen(ctx, "reddit-events").Result()
+	require.NoError(t, err)
+	assert.EqualValues(t, 1, length)
+}
+
+// TestRedisStreamSinkPublishDoesNotBlockOnBatchedFlush exercises the poller's
+// real usage pattern: Publish called sequentially, once per post, waiting
+// for each call to return before the next. With a batcher configured,
+// Publish must return well before the batch it enqueued into actually
+// flushes, or pipelining adds latency instead of removing it.
+func TestRedisStreamSinkPublishDoesNotBlockOnBatchedFlush(t *testing.T) {
+	ctx := context.Background()
+	mr := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	defer client.Close()
+
+	// Window long enough, and limit high enough, that nothing flushes
+	// during the sequential Publish calls below.
+	batcher := NewStreamBatcher(client, time.Hour, 10)
+
+	sink := newRedisStreamSink(client, batcher)
+
+	const posts = 3
+	start := time.Now()
+	for i := 0; i < posts; i++ {
+		post := RedditPost{ID: fmt.Sprintf("post%d", i), Subreddit: "wallstreetbets", Created: time.Now()}
+		require.NoError(t, sink.Publish(ctx, post))
+	}
+	elapsed := time.Since(start)
+
+	assert.Less(t, elapsed, 50*time.Millisecond, "Publish should enqueue and return immediately, not block on a flush")
+
+	length, err := client.XLen(ctx, "reddit-events").Result()
+	require.NoError(t, err)
+	assert.EqualValues(t, 0, length, "batch should still be buffered, proving the three Publish calls were coalesced rather than each flushing on its own")
+
+	// Closing flushes the buffered batch; all three posts should land in one shot.
+	batcher.Close()
+	length, err = client.XLen(ctx, "reddit-events").Result()
+	require.NoError(t, err)
+	assert.EqualValues(t, posts, length)
+}
+
+func TestPubsubSinkPublish(t *testing.T) {
+	ctx := context.Background()
+	mr := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	defer client.Close()
+
+	sub := client.Subscribe(ctx, "reddit-events:wallstreetbets")
+	defer sub.Close()
+	require.NoError(t, waitForSubscribe(ctx, sub))
+
+	sink := newPubsubSink(client)
+	post := RedditPost{ID: "post1", Title: "Test", Subreddit: "wallstreetbets", Created: time.Now()}
+	require.NoError(t, sink.Publish(ctx, post))
+
+	msg, err := sub.ReceiveMessage(ctx)
+	require.NoError(t, err)
+
+	var got RedditPost
+	require.NoError(t, json.Unmarshal([]byte(msg.Payload), &got))
+	assert.Equal(t, post.ID, got.ID)
+	assert.Equal(t, post.Subreddit, got.Subreddit)
+}
+
+// TestBothSinksReceiveSamePayload verifies that publishing through both the
+// stream sink and the pub/sub sink together delivers the same post to each.
+func TestBothSinksReceiveSamePayload(t *testing.T) {
+	ctx := context.Background()
+	mr := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	defer client.Close()
+
+	sub := client.Subscribe(ctx, "reddit-events:wallstreetbets")
+	defer sub.Close()
+	require.NoError(t, waitForSubscribe(ctx, sub))
+
+	sinks := []Sink{newRedisStreamSink(client, nil), newPubsubSink(client)}
+	created := reddit.Timestamp{Time: time.Now()}
+	post := &reddit.Post{ID: "post1", Title: "Test", SubredditName: "wallstreetbets", Created: &created}
+
+	publishPost(ctx, sinks, post)
+
+	length, err := client.XLen(ctx, "reddit-events").Result()
+	require.NoError(t, err)
+	assert.EqualValues(t, 1, length)
+
+	msg, err := sub.ReceiveMessage(ctx)
+	require.NoError(t, err)
+
+	var got RedditPost
+	require.NoError(t, json.Unmarshal([]byte(msg.Payload), &got))
+	assert.Equal(t, post.ID, got.ID)
+}
+
+func waitForSubscribe(ctx context.Context, sub *redis.PubSub) error {
+	_, err := sub.Receive(ctx)
+	return err
+}
@@ -0,0 +1,138 @@
+package main
+
+import (
+	"context"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// defaultPipelineWindow and defaultPipelineLimit mirror the implicit
+// pipelining defaults used by the poller when REDIS_PIPELINE_WINDOW /
+// REDIS_PIPELINE_LIMIT are unset.
+const (
+	defaultPipelineWindow = 50 * time.Millisecond
+	defaultPipelineLimit  = 128
+)
+
+// RedisPipeliner is the subset of *redis.Client the StreamBatcher needs to
+// flush a batch of XAdd calls in a single round trip.
+type RedisPipeliner interface {
+	Pipelined(ctx context.Context, fn func(redis.Pipeliner) error) ([]redis.Cmder, error)
+}
+
+// xaddRequest is a single buffered XAdd call awaiting a flush.
+type xaddRequest struct {
+	args     *redis.XAddArgs
+	callback func(id string, err error)
+}
+
+// StreamBatcher buffers XAdd calls and flushes them via a Redis pipeline
+// either when `window` elapses or `limit` items have queued up, whichever
+// comes first. It runs its own goroutine; callers enqueue and return
+// immediately, receiving the outcome later via a per-item callback.
+type StreamBatcher struct {
+	client  RedisPipeliner
+	window  time.Duration
+	limit   int
+	queue   chan xaddRequest
+	flushed chan struct{}
+}
+
+// NewStreamBatcher starts a StreamBatcher. Pass limit <= 0 to disable
+// batching entirely; callers should fall back to a direct XAdd in that case
+// (see shouldPipeline).
+func NewStreamBatcher(client RedisPipeliner, window time.Duration, limit int) *StreamBatcher {
+	b := &StreamBatcher{
+		client:  client,
+		window:  window,
+		limit:   limit,
+		queue:   make(chan xaddRequest, limit*2),
+		flushed: make(chan struct{}),
+	}
+	go b.run()
+	return b
+}
+
+// shouldPipeline reports whether pipelining is enabled for the given
+// window/limit configuration. Either being zero preserves the historical
+// per-call XAdd behavior.
+func shouldPipeline(window time.Duration, limit int) bool {
+	return window > 0 && limit > 0
+}
+
+// Enqueue buffers an XAdd call. callback is invoked with the result once
+// the batch containing it is flushed; callback may be nil.
+func (b *StreamBatcher) Enqueue(args *redis.XAddArgs, callback func(id string, err error)) {
+	b.queue <- xaddRequest{args: args, callback: callback}
+}
+
+// Close flushes any buffered items and stops the batcher's goroutine.
+func (b *StreamBatcher) Close() {
+	close(b.queue)
+	<-b.flushed
+}
+
+func (b *StreamBatcher) run() {
+	defer close(b.flushed)
+
+	batch := make([]xaddRequest, 0, b.limit)
+	timer := time.NewTimer(b.window)
+	defer timer.Stop()
+
+	for {
+		select {
+		case req, ok := <-b.queue:
+			if !ok {
+				b.flush(batch)
+				return
+			}
+			batch = append(batch, req)
+			if len(batch) >= b.limit {
+				b.flush(batch)
+				batch = batch[:0]
+				if !timer.Stop() {
+					<-timer.C
+				}
+				timer.Reset(b.window)
+			}
+
+		case <-timer.C:
+			b.flush(batch)
+			batch = batch[:0]
+			timer.Reset(b.window)
+		}
+	}
+}
+
+// flush pipelines every buffered XAdd and reports each result back through
+// its callback. Flushes intentionally use their own background context
+// rather than any single caller's: a batch mixes items from several
+// Enqueue calls, so no one caller's cancellation should abort another's
+// write. Close still guarantees the final flush runs before it returns.
+func (b *StreamBatcher) flush(batch []xaddRequest) {
+	if len(batch) == 0 {
+		return
+	}
+
+	flushCtx := context.Background()
+	cmds := make([]*redis.StringCmd, len(batch))
+	_, pipelineErr := b.client.Pipelined(flushCtx, func(pipe redis.Pipeliner) error {
+		for i, req := range batch {
+			cmds[i] = pipe.XAdd(flushCtx, req.args)
+		}
+		return nil
+	})
+
+	for i, req := range batch {
+		if req.callback == nil {
+			continue
+		}
+		if pipelineErr != nil {
+			req.callback("", pipelineErr)
+			continue
+		}
+		id, err := cmds[i].Result()
+		req.callback(id, err)
+	}
+}
@@ -0,0 +1,117 @@
+package main
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/go-redis/redis/v8"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStreamBatcherFlushesOnLimit(t *testing.T) {
+	mr := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	defer client.Close()
+
+	batcher := NewStreamBatcher(client, time.Hour, 3) // window long enough that only the limit triggers flushes
+	defer batcher.Close()
+
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	var errs []error
+
+	for i := 0; i < 3; i++ {
+		wg.Add(1)
+		batcher.Enqueue(&redis.XAddArgs{Stream: "reddit-events", Values: map[string]interface{}{"id": "p"}}, func(id string, err error) {
+			defer wg.Done()
+			mu.Lock()
+			errs = append(errs, err)
+			mu.Unlock()
+		})
+	}
+
+	waitOrTimeout(t, &wg, time.Second)
+
+	for _, err := range errs {
+		assert.NoError(t, err)
+	}
+
+	length, err := client.XLen(context.Background(), "reddit-events").Result()
+	require.NoError(t, err)
+	assert.EqualValues(t, 3, length)
+}
+
+func TestStreamBatcherFlushesOnWindow(t *testing.T) {
+	mr := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	defer client.Close()
+
+	batcher := NewStreamBatcher(client, 20*time.Millisecond, 100) // limit high enough that only the window triggers a flush
+	defer batcher.Close()
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	batcher.Enqueue(&redis.XAddArgs{Stream: "reddit-events", Values: map[string]interface{}{"id": "p"}}, func(id string, err error) {
+		defer wg.Done()
+		assert.NoError(t, err)
+	})
+
+	waitOrTimeout(t, &wg, time.Second)
+}
+
+func TestShouldPipeline(t *testing.T) {
+	assert.True(t, shouldPipeline(50*time.Millisecond, 128))
+	assert.False(t, shouldPipeline(0, 128))
+	assert.False(t, shouldPipeline(50*time.Millisecond, 0))
+}
+
+func waitOrTimeout(t *testing.T, wg *sync.WaitGroup, timeout time.Duration) {
+	t.Helper()
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(timeout):
+		t.Fatal("timed out waiting for batch to flush")
+	}
+}
+
+// BenchmarkXAddSingle measures the cost of issuing one XAdd per post.
+func BenchmarkXAddSingle(b *testing.B) {
+	mr := miniredis.RunT(b)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	defer client.Close()
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		client.XAdd(context.Background(), &redis.XAddArgs{Stream: "bench", Values: map[string]interface{}{"id": "p"}})
+	}
+}
+
+// BenchmarkXAddPipelined measures the same workload buffered through a
+// StreamBatcher instead of issuing a round trip per post.
+func BenchmarkXAddPipelined(b *testing.B) {
+	mr := miniredis.RunT(b)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	defer client.Close()
+
+	batcher := NewStreamBatcher(client, 10*time.Millisecond, 128)
+	defer batcher.Close()
+
+	var wg sync.WaitGroup
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		wg.Add(1)
+		batcher.Enqueue(&redis.XAddArgs{Stream: "bench", Values: map[string]interface{}{"id": "p"}}, func(id string, err error) {
+			wg.Done()
+		})
+	}
+	wg.Wait()
+}
@@ -18,12 +18,6 @@ func (m *MockRedisStreamer) XAdd(ctx context.Context, args *XAddArgs) (string, e
 	return called.String(0), called.Error(1)
 }
 
-// MockRedditClient mocks the Reddit client
-type MockRedditClient struct {
-	mock.Mock
-	Subreddit *MockSubreddit
-}
-
 // MockSubreddit mocks the Subreddit service
 type MockSubreddit struct {
 	mock.Mock
@@ -33,4 +27,4 @@ type MockSubreddit struct {
 func (m *MockSubreddit) NewPosts(ctx context.Context, subreddit string, opts *reddit.ListOptions) ([]*reddit.Post, *reddit.Response, error) {
 	args := m.Called(ctx, subreddit, opts)
 	return args.Get(0).([]*reddit.Post), args.Get(1).(*reddit.Response), args.Error(2)
-}
\ No newline at end of file
+}
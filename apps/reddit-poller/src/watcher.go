@@ -0,0 +1,434 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log"
+	"math/rand"
+	"net/http"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	_ "github.com/jackc/pgx/v5/stdlib"
+)
+
+// DestinationKind identifies where a matched post should be fanned out to.
+type DestinationKind string
+
+const (
+	DestinationStream  DestinationKind = "stream"  // the existing Redis stream (default)
+	DestinationWebhook DestinationKind = "webhook" // an HTTP callback URL
+	DestinationPush    DestinationKind = "push"    // an APNs/FCM token
+)
+
+const (
+	watcherDispatchRetries     = 3
+	watcherDispatchBaseDelay   = 200 * time.Millisecond
+	watcherDispatchHTTPTimeout = 5 * time.Second
+)
+
+// Destination describes the delivery target for a Watcher match.
+type Destination struct {
+	Kind   DestinationKind   `json:"destination_kind"`
+	Config map[string]string `json:"destination_config"`
+}
+
+// Watcher is a persistent subscription that fans matching posts in a
+// subreddit out to a Destination. Patterns are matched case-insensitively
+// and are compiled once when the watcher is loaded.
+type Watcher struct {
+	ID          uuid.UUID   `json:"id"`
+	Subreddit   string      `json:"subreddit"`
+	AuthorRe    string      `json:"author_pattern"`
+	TitleRe     string      `json:"title_pattern"`
+	BodyRe      string      `json:"body_pattern"`
+	Destination Destination `json:"destination"`
+	CreatedAt   time.Time   `json:"created_at"`
+
+	authorRe *regexp.Regexp
+	titleRe  *regexp.Regexp
+	bodyRe   *regexp.Regexp
+}
+
+// compile compiles the watcher's text patterns into case-insensitive
+// regexes. An empty pattern matches everything.
+func (w *Watcher) compile() error {
+	compiled, err := compileCaseInsensitive(w.AuthorRe)
+	if err != nil {
+		return fmt.Errorf("author_pattern: %w", err)
+	}
+	w.authorRe = compiled
+
+	compiled, err = compileCaseInsensitive(w.TitleRe)
+	if err != nil {
+		return fmt.Errorf("title_pattern: %w", err)
+	}
+	w.titleRe = compiled
+
+	compiled, err = compileCaseInsensitive(w.BodyRe)
+	if err != nil {
+		return fmt.Errorf("body_pattern: %w", err)
+	}
+	w.bodyRe = compiled
+
+	return nil
+}
+
+func compileCaseInsensitive(pattern string) (*regexp.Regexp, error) {
+	if pattern == "" {
+		return nil, nil
+	}
+	return regexp.Compile("(?i)" + pattern)
+}
+
+// matches reports whether post satisfies the watcher's subreddit and regex
+// filters.
+func (w *Watcher) matches(post RedditPost) bool {
+	if !strings.EqualFold(w.Subreddit, post.Subreddit) {
+		return false
+	}
+	if w.authorRe != nil && !w.authorRe.MatchString(post.Author) {
+		return false
+	}
+	if w.titleRe != nil && !w.titleRe.MatchString(post.Title) {
+		return false
+	}
+	if w.bodyRe != nil && !w.bodyRe.MatchString(post.Body) {
+		return false
+	}
+	return true
+}
+
+// WatcherEvaluator decides which watchers a post matches. It is an
+// interface so publishPost can be unit-tested without a real registry.
+type WatcherEvaluator interface {
+	Evaluate(post RedditPost) []Watcher
+}
+
+// WatcherStore persists watchers in Postgres.
+type WatcherStore interface {
+	List(ctx context.Context) ([]Watcher, error)
+	Create(ctx context.Context, w Watcher) (Watcher, error)
+	Delete(ctx context.Context, id uuid.UUID) error
+}
+
+// watcherRegistry is the in-memory, compiled view of the watchers stored in
+// WatcherStore. It implements WatcherEvaluator and is refreshed whenever a
+// watcher is added or removed through the HTTP API.
+type watcherRegistry struct {
+	mu    sync.RWMutex
+	byID  map[uuid.UUID]*Watcher
+	store WatcherStore
+}
+
+func newWatcherRegistry(store WatcherStore) *watcherRegistry {
+	return &watcherRegistry{
+		byID:  make(map[uuid.UUID]*Watcher),
+		store: store,
+	}
+}
+
+// load fetches all watchers from the store and compiles their patterns.
+func (r *watcherRegistry) load(ctx context.Context) error {
+	watchers, err := r.store.List(ctx)
+	if err != nil {
+		return fmt.Errorf("loading watchers: %w", err)
+	}
+
+	byID := make(map[uuid.UUID]*Watcher, len(watchers))
+	for i := range watchers {
+		w := watchers[i]
+		if err := w.compile(); err != nil {
+			log.Printf("skipping watcher %s: %v", w.ID, err)
+			continue
+		}
+		byID[w.ID] = &w
+	}
+
+	r.mu.Lock()
+	r.byID = byID
+	r.mu.Unlock()
+	return nil
+}
+
+// Evaluate returns the watchers whose subreddit and regex filters match post.
+func (r *watcherRegistry) Evaluate(post RedditPost) []Watcher {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var matched []Watcher
+	for _, w := range r.byID {
+		if w.matches(post) {
+			matched = append(matched, *w)
+		}
+	}
+	return matched
+}
+
+// Add creates a watcher in the store and adds it to the in-memory registry.
+func (r *watcherRegistry) Add(ctx context.Context, w Watcher) (Watcher, error) {
+	if err := w.compile(); err != nil {
+		return Watcher{}, err
+	}
+
+	created, err := r.store.Create(ctx, w)
+	if err != nil {
+		return Watcher{}, err
+	}
+	if err := created.compile(); err != nil {
+		return Watcher{}, err
+	}
+
+	r.mu.Lock()
+	r.byID[created.ID] = &created
+	r.mu.Unlock()
+	return created, nil
+}
+
+// Remove deletes a watcher from the store and the in-memory registry.
+func (r *watcherRegistry) Remove(ctx context.Context, id uuid.UUID) error {
+	if err := r.store.Delete(ctx, id); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	delete(r.byID, id)
+	r.mu.Unlock()
+	return nil
+}
+
+// List returns a snapshot of all currently loaded watchers.
+func (r *watcherRegistry) List() []Watcher {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	watchers := make([]Watcher, 0, len(r.byID))
+	for _, w := range r.byID {
+		watchers = append(watchers, *w)
+	}
+	return watchers
+}
+
+// postgresWatcherStore is the Postgres-backed WatcherStore implementation.
+type postgresWatcherStore struct {
+	db *sql.DB
+}
+
+func newPostgresWatcherStore(db *sql.DB) *postgresWatcherStore {
+	return &postgresWatcherStore{db: db}
+}
+
+func (s *postgresWatcherStore) List(ctx context.Context) ([]Watcher, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT id, subreddit, author_pattern, title_pattern, body_pattern,
+		       destination_kind, destination_config, created_at
+		FROM watchers`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var watchers []Watcher
+	for rows.Next() {
+		w, err := scanWatcher(rows)
+		if err != nil {
+			return nil, err
+		}
+		watchers = append(watchers, w)
+	}
+	return watchers, rows.Err()
+}
+
+func (s *postgresWatcherStore) Create(ctx context.Context, w Watcher) (Watcher, error) {
+	if w.ID == uuid.Nil {
+		w.ID = uuid.New()
+	}
+	w.CreatedAt = time.Now().UTC()
+
+	config, err := json.Marshal(w.Destination.Config)
+	if err != nil {
+		return Watcher{}, fmt.Errorf("marshalling destination_config: %w", err)
+	}
+
+	_, err = s.db.ExecContext(ctx, `
+		INSERT INTO watchers
+			(id, subreddit, author_pattern, title_pattern, body_pattern,
+			 destination_kind, destination_config, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)`,
+		w.ID, w.Subreddit, w.AuthorRe, w.TitleRe, w.BodyRe,
+		w.Destination.Kind, config, w.CreatedAt)
+	if err != nil {
+		return Watcher{}, err
+	}
+	return w, nil
+}
+
+func (s *postgresWatcherStore) Delete(ctx context.Context, id uuid.UUID) error {
+	_, err := s.db.ExecContext(ctx, `DELETE FROM watchers WHERE id = $1`, id)
+	return err
+}
+
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanWatcher(row rowScanner) (Watcher, error) {
+	var w Watcher
+	var config []byte
+	if err := row.Scan(&w.ID, &w.Subreddit, &w.AuthorRe, &w.TitleRe, &w.BodyRe,
+		&w.Destination.Kind, &config, &w.CreatedAt); err != nil {
+		return Watcher{}, err
+	}
+	if len(config) > 0 {
+		if err := json.Unmarshal(config, &w.Destination.Config); err != nil {
+			return Watcher{}, fmt.Errorf("unmarshalling destination_config: %w", err)
+		}
+	}
+	return w, nil
+}
+
+// watcherDispatcher fans a post out to every matched watcher's destination
+// concurrently, retrying each destination independently with backoff.
+type watcherDispatcher struct {
+	httpClient *http.Client
+}
+
+func newWatcherDispatcher() *watcherDispatcher {
+	return &watcherDispatcher{
+		httpClient: &http.Client{Timeout: watcherDispatchHTTPTimeout},
+	}
+}
+
+// Dispatch delivers post to every watcher in matches concurrently.
+func (d *watcherDispatcher) Dispatch(ctx context.Context, matches []Watcher, post RedditPost) {
+	var wg sync.WaitGroup
+	for _, w := range matches {
+		w := w
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := d.sendWithRetry(ctx, w, post); err != nil {
+				log.Printf("watcher %s dispatch to %s failed: %v", w.ID, w.Destination.Kind, err)
+				recordError(fmt.Sprintf("watcher %s dispatch failed: %v", w.ID, err))
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+func (d *watcherDispatcher) sendWithRetry(ctx context.Context, w Watcher, post RedditPost) error {
+	delay := watcherDispatchBaseDelay
+	var lastErr error
+	for attempt := 0; attempt <= watcherDispatchRetries; attempt++ {
+		if attempt > 0 {
+			jitter := time.Duration(rand.Int63n(int64(delay)))
+			select {
+			case <-time.After(delay + jitter):
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+			delay *= 2
+		}
+
+		if lastErr = d.send(ctx, w, post); lastErr == nil {
+			return nil
+		}
+	}
+	return lastErr
+}
+
+func (d *watcherDispatcher) send(ctx context.Context, w Watcher, post RedditPost) error {
+	switch w.Destination.Kind {
+	case DestinationStream:
+		// Delivery to the default Redis stream is handled by the poller's
+		// normal publishPost path; nothing extra to dispatch here.
+		return nil
+	case DestinationWebhook:
+		return d.sendHTTP(ctx, w.Destination.Config["url"], post, nil)
+	case DestinationPush:
+		token := w.Destination.Config["token"]
+		return d.sendHTTP(ctx, w.Destination.Config["url"], post, map[string]string{"X-Push-Token": token})
+	default:
+		return fmt.Errorf("unknown destination kind %q", w.Destination.Kind)
+	}
+}
+
+func (d *watcherDispatcher) sendHTTP(ctx context.Context, url string, post RedditPost, headers map[string]string) error {
+	if url == "" {
+		return fmt.Errorf("destination missing url")
+	}
+
+	body, err := json.Marshal(post)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+
+	resp, err := d.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("destination returned status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// registerWatcherRoutes mounts CRUD handlers for watchers on the health
+// server's mux.
+func registerWatcherRoutes(mux *http.ServeMux, registry *watcherRegistry) {
+	mux.HandleFunc("/watchers", func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			w.Header().Set("Content-Type", "application/json")
+			json.NewEncoder(w).Encode(registry.List())
+
+		case http.MethodPost:
+			var watcher Watcher
+			if err := json.NewDecoder(r.Body).Decode(&watcher); err != nil {
+				http.Error(w, "invalid request body", http.StatusBadRequest)
+				return
+			}
+			created, err := registry.Add(r.Context(), watcher)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusCreated)
+			json.NewEncoder(w).Encode(created)
+
+		case http.MethodDelete:
+			idParam := r.URL.Query().Get("id")
+			id, err := uuid.Parse(idParam)
+			if err != nil {
+				http.Error(w, "invalid or missing id", http.StatusBadRequest)
+				return
+			}
+			if err := registry.Remove(r.Context(), id); err != nil {
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+			w.WriteHeader(http.StatusNoContent)
+
+		default:
+			w.WriteHeader(http.StatusMethodNotAllowed)
+		}
+	})
+}
@@ -0,0 +1,108 @@
+package main
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeWatcherStore is an in-memory WatcherStore used for testing the
+// registry without a real Postgres connection.
+type fakeWatcherStore struct {
+	watchers map[uuid.UUID]Watcher
+}
+
+func newFakeWatcherStore(watchers ...Watcher) *fakeWatcherStore {
+	store := &fakeWatcherStore{watchers: make(map[uuid.UUID]Watcher)}
+	for _, w := range watchers {
+		store.watchers[w.ID] = w
+	}
+	return store
+}
+
+func (s *fakeWatcherStore) List(ctx context.Context) ([]Watcher, error) {
+	watchers := make([]Watcher, 0, len(s.watchers))
+	for _, w := range s.watchers {
+		watchers = append(watchers, w)
+	}
+	return watchers, nil
+}
+
+func (s *fakeWatcherStore) Create(ctx context.Context, w Watcher) (Watcher, error) {
+	if w.ID == uuid.Nil {
+		w.ID = uuid.New()
+	}
+	w.CreatedAt = time.Now().UTC()
+	s.watchers[w.ID] = w
+	return w, nil
+}
+
+func (s *fakeWatcherStore) Delete(ctx context.Context, id uuid.UUID) error {
+	delete(s.watchers, id)
+	return nil
+}
+
+func TestWatcherRegistryEvaluateMatchesSubredditCaseInsensitively(t *testing.T) {
+	store := newFakeWatcherStore(Watcher{
+		ID:        uuid.New(),
+		Subreddit: "WallStreetBets",
+		TitleRe:   "moon",
+	})
+	registry := newWatcherRegistry(store)
+	assert.NoError(t, registry.load(context.Background()))
+
+	matched := registry.Evaluate(RedditPost{
+		Subreddit: "wallstreetbets",
+		Title:     "GME to the MOON",
+	})
+	assert.Len(t, matched, 1)
+
+	notMatched := registry.Evaluate(RedditPost{
+		Subreddit: "wallstreetbets",
+		Title:     "just a regular update",
+	})
+	assert.Empty(t, notMatched)
+}
+
+func TestWatcherRegistryAddAndRemove(t *testing.T) {
+	store := newFakeWatcherStore()
+	registry := newWatcherRegistry(store)
+	assert.NoError(t, registry.load(context.Background()))
+
+	created, err := registry.Add(context.Background(), Watcher{
+		Subreddit:   "pennystocks",
+		AuthorRe:    "^bot_",
+		Destination: Destination{Kind: DestinationWebhook, Config: map[string]string{"url": "https://example.com/hook"}},
+	})
+	assert.NoError(t, err)
+	assert.NotEqual(t, uuid.Nil, created.ID)
+	assert.Len(t, registry.List(), 1)
+
+	matched := registry.Evaluate(RedditPost{Subreddit: "pennystocks", Author: "bot_alerts"})
+	assert.Len(t, matched, 1)
+
+	assert.NoError(t, registry.Remove(context.Background(), created.ID))
+	assert.Empty(t, registry.List())
+}
+
+func TestWatcherDispatchSkipsStreamDestination(t *testing.T) {
+	d := newWatcherDispatcher()
+	w := Watcher{ID: uuid.New(), Destination: Destination{Kind: DestinationStream}}
+
+	// A stream destination has nothing to deliver out-of-band, so dispatch
+	// should return without making any network calls.
+	done := make(chan struct{})
+	go func() {
+		d.Dispatch(context.Background(), []Watcher{w}, RedditPost{ID: "p1"})
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("Dispatch did not return for a stream-only destination")
+	}
+}